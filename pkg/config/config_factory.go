@@ -4,18 +4,35 @@ import (
 	"aws-observability.io/collector/pkg/consts"
 	"bytes"
 	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/spf13/viper"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config"
 	"go.opentelemetry.io/collector/config/configmodels"
 	"go.opentelemetry.io/collector/service"
+	"log"
 	"os"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// OnReload, when non-nil, is invoked with the freshly loaded config whenever the SSM
+// config watcher detects that the backing parameter changed. Nothing in this package
+// sets it; while it is nil, a detected change is logged and otherwise ignored.
+var OnReload func(*configmodels.Config)
+
+// ssmWatchOnce ensures only one polling goroutine is ever started per process, even if
+// the factory func returned by GetCfgFactory is invoked more than once (e.g. a reload
+// path calling back into config loading).
+var ssmWatchOnce sync.Once
+
 // GetCfgFactory returns AOC/Otel config
 func GetCfgFactory() func(otelViper *viper.Viper, f component.Factories) (*configmodels.Config, error) {
 	return func(otelViper *viper.Viper, f component.Factories) (*configmodels.Config, error) {
-		// AOC supports loading yaml config from SSM parameter store
+		// AOC supports loading yaml config content directly from an env var
 		if ssmConfigContent, ok := os.LookupEnv(consts.AOC_CONFIG_CONTENT); ok &&
 			os.Getenv(consts.RUN_IN_CONTAINER) == consts.RUN_IN_CONTAINER_TRUE {
 			fmt.Printf("Reading json consts from from environment: %v = %v\n",
@@ -23,6 +40,24 @@ func GetCfgFactory() func(otelViper *viper.Viper, f component.Factories) (*confi
 			return sSMConfigLoader(otelViper, f, ssmConfigContent)
 		}
 
+		// AOC also supports loading yaml config from an SSM parameter *name*, fetching
+		// (and decrypting, if it's a SecureString) its value via the AWS SDK. This lets
+		// operators store secrets like access keys inline in the parameter instead of
+		// baking them into the container environment.
+		if ssmConfigName, ok := os.LookupEnv(consts.AOC_CONFIG_SSM_NAME); ok &&
+			os.Getenv(consts.RUN_IN_CONTAINER) == consts.RUN_IN_CONTAINER_TRUE {
+			ssmConfigContent, err := fetchSSMParameter(ssmConfigName)
+			if err != nil {
+				return nil, fmt.Errorf("error fetching SSM consts parameter %v: %v", ssmConfigName, err)
+			}
+			otelCfg, err := sSMConfigLoader(otelViper, f, ssmConfigContent)
+			if err != nil {
+				return nil, err
+			}
+			watchSSMConfig(ssmConfigName, ssmConfigContent, otelViper, f, fetchSSMParameter)
+			return otelCfg, nil
+		}
+
 		// use OTel yaml consts from input
 		otelCfg, err := service.FileLoaderConfigFactory(otelViper, f)
 		if err != nil {
@@ -44,3 +79,71 @@ func sSMConfigLoader(v *viper.Viper,
 	}
 	return config.Load(v, factories)
 }
+
+// fetchSSMParameter fetches parameterName from SSM Parameter Store, requesting
+// decryption so SecureString parameters come back in plaintext.
+func fetchSSMParameter(parameterName string) (string, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return "", err
+	}
+	output, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(parameterName),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// watchSSMConfig polls parameterName on an interval (seconds, configured via
+// AOC_CONFIG_SSM_POLL_INTERVAL; polling is disabled when that's unset or <= 0) and, once
+// its content changes from initialContent, reloads it and calls OnReload with the
+// resulting config. fetch is called to get the parameter's current content on each tick;
+// production callers pass fetchSSMParameter, tests can inject a fake instead.
+func watchSSMConfig(parameterName, initialContent string, v *viper.Viper, factories component.Factories, fetch func(string) (string, error)) {
+	intervalSeconds, err := strconv.Atoi(os.Getenv(consts.AOC_CONFIG_SSM_POLL_INTERVAL))
+	if err != nil || intervalSeconds <= 0 {
+		return
+	}
+
+	ssmWatchOnce.Do(func() {
+		go pollSSMConfig(parameterName, initialContent, time.Duration(intervalSeconds)*time.Second, fetch, func(content string) (*configmodels.Config, error) {
+			return sSMConfigLoader(v, factories, content)
+		})
+	})
+}
+
+// pollSSMConfig is the polling loop started by watchSSMConfig, split out so its
+// skip/reload/warn branching can be unit-tested with fake fetch and load funcs instead of
+// the real AWS SDK and a real viper/factories pair.
+func pollSSMConfig(parameterName, initialContent string, interval time.Duration, fetch func(string) (string, error), load func(string) (*configmodels.Config, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastContent := initialContent
+	for range ticker.C {
+		content, err := fetch(parameterName)
+		if err != nil {
+			log.Printf("E! config: failed to poll SSM consts parameter %v: %v", parameterName, err)
+			continue
+		}
+		if content == lastContent {
+			continue
+		}
+		lastContent = content
+
+		log.Printf("I! config: detected a change in SSM consts parameter %v, reconfiguring the collector", parameterName)
+		newCfg, err := load(content)
+		if err != nil {
+			log.Printf("E! config: failed to reload consts from SSM parameter %v: %v", parameterName, err)
+			continue
+		}
+		if OnReload != nil {
+			OnReload(newCfg)
+		} else {
+			log.Printf("W! config: SSM consts parameter %v changed but no OnReload handler is registered, ignoring", parameterName)
+		}
+	}
+}
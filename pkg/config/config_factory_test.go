@@ -0,0 +1,182 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/configmodels"
+)
+
+//fakeSSMFetch returns successive entries from contents on each call, signalling on calls
+//so a test can wait for a specific number of polls instead of sleeping a fixed guess.
+//pollSSMConfig's loop never exits, so once contents is exhausted the fetch blocks forever
+//rather than repeating the last value - that keeps the poller from racing with a later
+//test's global OnReload/state once this test has asserted what it needs.
+func fakeSSMFetch(contents []string, calls chan<- struct{}) func(string) (string, error) {
+	i := 0
+	return func(string) (string, error) {
+		if i >= len(contents) {
+			select {}
+		}
+		content := contents[i]
+		i++
+		calls <- struct{}{}
+		return content, nil
+	}
+}
+
+func waitForCall(t *testing.T, calls <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a poll")
+	}
+}
+
+//TestPollSSMConfigSkipsWhenContentUnchanged guards the continue in pollSSMConfig's
+//content==lastContent branch: an unchanged parameter must not invoke load or OnReload.
+func TestPollSSMConfigSkipsWhenContentUnchanged(t *testing.T) {
+	calls := make(chan struct{}, 2)
+	fetch := fakeSSMFetch([]string{"same", "same"}, calls)
+	loadCalled := false
+	load := func(content string) (*configmodels.Config, error) {
+		loadCalled = true
+		return &configmodels.Config{}, nil
+	}
+
+	go pollSSMConfig("param", "same", time.Millisecond, fetch, load)
+	waitForCall(t, calls)
+	// Wait for the second tick to start: since pollSSMConfig runs its whole loop body on
+	// a single goroutine, fetch being entered again proves the first tick's branching
+	// (here, the skip) already ran to completion - a safe, race-free point to inspect
+	// loadCalled from the test goroutine.
+	waitForCall(t, calls)
+
+	if loadCalled {
+		t.Fatalf("expected load not to be called when SSM content is unchanged")
+	}
+}
+
+//TestPollSSMConfigWarnsWhenOnReloadUnset guards the else branch that logs instead of
+//dereferencing a nil OnReload.
+func TestPollSSMConfigWarnsWhenOnReloadUnset(t *testing.T) {
+	prevOnReload := OnReload
+	OnReload = nil
+	defer func() { OnReload = prevOnReload }()
+
+	calls := make(chan struct{}, 2)
+	fetch := fakeSSMFetch([]string{"changed", "changed"}, calls)
+	load := func(content string) (*configmodels.Config, error) {
+		return &configmodels.Config{}, nil
+	}
+
+	go pollSSMConfig("param", "initial", time.Millisecond, fetch, load)
+	waitForCall(t, calls)
+	waitForCall(t, calls) // second tick entering fetch proves the first tick's OnReload check already ran
+}
+
+//TestPollSSMConfigCallsOnReloadWhenContentChanges guards the happy path: a changed
+//parameter that loads successfully must be handed to OnReload.
+func TestPollSSMConfigCallsOnReloadWhenContentChanges(t *testing.T) {
+	prevOnReload := OnReload
+	defer func() { OnReload = prevOnReload }()
+
+	var mutex sync.Mutex
+	var gotCfg *configmodels.Config
+	OnReload = func(cfg *configmodels.Config) {
+		mutex.Lock()
+		gotCfg = cfg
+		mutex.Unlock()
+	}
+
+	calls := make(chan struct{}, 2)
+	fetch := fakeSSMFetch([]string{"changed", "changed"}, calls)
+	wantCfg := &configmodels.Config{}
+	load := func(content string) (*configmodels.Config, error) {
+		if content != "changed" {
+			t.Errorf("load called with %q, want %q", content, "changed")
+		}
+		return wantCfg, nil
+	}
+
+	go pollSSMConfig("param", "initial", time.Millisecond, fetch, load)
+	waitForCall(t, calls)
+	waitForCall(t, calls) // second tick entering fetch proves the first tick's OnReload call already ran
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if gotCfg != wantCfg {
+		t.Fatalf("OnReload received %v, want %v", gotCfg, wantCfg)
+	}
+}
+
+//TestPollSSMConfigSkipsReloadOnLoadError guards the continue after a failed load: a
+//changed parameter whose content fails to parse must not reach OnReload.
+func TestPollSSMConfigSkipsReloadOnLoadError(t *testing.T) {
+	prevOnReload := OnReload
+	reloadCalled := false
+	OnReload = func(cfg *configmodels.Config) { reloadCalled = true }
+	defer func() { OnReload = prevOnReload }()
+
+	calls := make(chan struct{}, 2)
+	fetch := fakeSSMFetch([]string{"broken", "broken"}, calls)
+	load := func(content string) (*configmodels.Config, error) {
+		return nil, errors.New("bad yaml")
+	}
+
+	go pollSSMConfig("param", "initial", time.Millisecond, fetch, load)
+	waitForCall(t, calls)
+	waitForCall(t, calls) // second tick entering fetch proves the first tick's error handling already ran
+
+	if reloadCalled {
+		t.Fatalf("expected OnReload not to be called when load fails")
+	}
+}
+
+//TestWatchSSMConfigStartsOnlyOneGoroutine guards ssmWatchOnce: calling watchSSMConfig a
+//second time, even with a different parameter and fetch func, must not start a second
+//polling goroutine.
+func TestWatchSSMConfigStartsOnlyOneGoroutine(t *testing.T) {
+	prevInterval := os.Getenv("AOC_CONFIG_SSM_POLL_INTERVAL")
+	os.Setenv("AOC_CONFIG_SSM_POLL_INTERVAL", "1")
+	defer os.Setenv("AOC_CONFIG_SSM_POLL_INTERVAL", prevInterval)
+
+	// fetch always returns the same content as the initial value, so the poller never
+	// reaches sSMConfigLoader (which would need a real viper/factories pair we don't have
+	// here) while still exercising ssmWatchOnce via a live polling goroutine.
+	firstCalls := make(chan struct{}, 4)
+	first := fakeSSMFetch([]string{"a", "a", "a", "a"}, firstCalls)
+	watchSSMConfig("first", "a", nil, component.Factories{}, first)
+	waitForCall(t, firstCalls)
+
+	secondCalled := false
+	second := func(string) (string, error) {
+		secondCalled = true
+		return "b", nil
+	}
+	watchSSMConfig("second", "initial", nil, component.Factories{}, second)
+
+	waitForCall(t, firstCalls)
+	if secondCalled {
+		t.Fatalf("expected the second watchSSMConfig call to be a no-op once a poller is already running")
+	}
+}
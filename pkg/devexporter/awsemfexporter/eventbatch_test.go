@@ -0,0 +1,89 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsemfexporter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEventBatchAddAccumulatesUntilFull(t *testing.T) {
+	b := newEventBatch()
+	for i := 0; i < MaxRequestEventCount; i++ {
+		logEvent := NewLogEvent(1000, "hi", "f", 0)
+		if overflow := b.add(logEvent); overflow != nil {
+			t.Fatalf("event %d: unexpected overflow before the batch is full", i)
+		}
+	}
+	if !b.full() {
+		t.Fatalf("expected batch to be full after %d events", MaxRequestEventCount)
+	}
+
+	overflow := b.add(NewLogEvent(1000, "one too many", "f", 0))
+	if overflow == nil {
+		t.Fatalf("expected the event count limit to overflow the (%d+1)th event", MaxRequestEventCount)
+	}
+}
+
+func TestEventBatchAddOverflowsOnByteBudget(t *testing.T) {
+	b := newEventBatch()
+	big := NewLogEvent(1000, strings.Repeat("a", MaxRequestPayloadBytes-PerEventHeaderBytes), "f", 0)
+	if overflow := b.add(big); overflow != nil {
+		t.Fatalf("unexpected overflow for an event that exactly fills the byte budget")
+	}
+
+	overflow := b.add(NewLogEvent(1000, "a", "f", 0))
+	if overflow == nil {
+		t.Fatalf("expected an event pushing byteTotal past MaxRequestPayloadBytes to overflow")
+	}
+}
+
+func TestEventBatchAddOverflowsOutside24HourWindow(t *testing.T) {
+	b := newEventBatch()
+	const start = int64(1000)
+	if overflow := b.add(NewLogEvent(start, "first", "f", 0)); overflow != nil {
+		t.Fatalf("unexpected overflow for the first event in the batch")
+	}
+
+	tooLate := start + int64(25*3600*1e3)
+	overflow := b.add(NewLogEvent(tooLate, "25h later", "f", 0))
+	if overflow == nil {
+		t.Fatalf("expected an event more than 24 hours after the batch minimum to overflow")
+	}
+}
+
+func TestEventBatchReset(t *testing.T) {
+	b := newEventBatch()
+	b.add(NewLogEvent(1000, "hello", "f", 0))
+	if len(b.logEvents) == 0 || b.byteTotal == 0 {
+		t.Fatalf("expected the batch to have accounted for the added event before reset")
+	}
+
+	b.reset()
+	if len(b.logEvents) != 0 {
+		t.Fatalf("reset should clear logEvents, got len %d", len(b.logEvents))
+	}
+	if b.byteTotal != 0 || b.minTimestampInMillis != 0 || b.maxTimestampInMillis != 0 {
+		t.Fatalf("reset should clear byteTotal and min/max timestamps")
+	}
+	if cap(b.logEvents) == 0 {
+		t.Fatalf("reset should keep the underlying array so it can be reused")
+	}
+
+	// the reset batch should accept new events exactly like a fresh one.
+	if overflow := b.add(NewLogEvent(2000, "reused", "f", 0)); overflow != nil {
+		t.Fatalf("unexpected overflow adding to a reset batch")
+	}
+}
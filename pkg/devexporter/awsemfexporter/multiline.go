@@ -0,0 +1,95 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsemfexporter
+
+import (
+	"regexp"
+	"time"
+)
+
+//MultilineConfig holds the exporter options that control multiline log aggregation,
+//analogous to the awslogs driver's awslogs-multiline-pattern / awslogs-datetime-format
+//options. StartPattern, when it matches a line, marks the start of a new logical log
+//event; lines that don't match are appended to the previous event instead. FlushTimeout
+//bounds how long an in-progress event is held when no start-of-event line ever arrives
+//to close it out.
+type MultilineConfig struct {
+	StartPattern *regexp.Regexp
+	FlushTimeout time.Duration
+}
+
+//multilineLogEventBuffer wraps NewLogEvent with multiline aggregation: lines that don't
+//match the configured StartPattern are appended to the in-progress LogEvent rather than
+//becoming events of their own.
+type multilineLogEventBuffer struct {
+	cfg     MultilineConfig
+	pending *LogEvent
+}
+
+//newMultilineLogEventBuffer creates a buffer for the given config. A zero-value
+//MultilineConfig (nil StartPattern) makes every line start its own event; each event is
+//still held as pending and only returned by the next AddLine or Flush call.
+func newMultilineLogEventBuffer(cfg MultilineConfig) *multilineLogEventBuffer {
+	return &multilineLogEventBuffer{cfg: cfg}
+}
+
+//AddLine feeds a single line into the buffer. When the line starts a new logical event
+//(it matches cfg.StartPattern, or the pending event has been held longer than
+//cfg.FlushTimeout), the previously pending event is returned so the caller can push it
+//downstream; otherwise AddLine returns nil while the line is folded into the pending
+//event.
+func (b *multilineLogEventBuffer) AddLine(timestampInMillis int64, line string, filename string, position int64) *LogEvent {
+	startsNewEvent := b.pending == nil || b.cfg.StartPattern == nil || b.cfg.StartPattern.MatchString(line)
+	if !startsNewEvent && b.cfg.FlushTimeout > 0 && time.Since(b.pending.LogGeneratedTime) >= b.cfg.FlushTimeout {
+		startsNewEvent = true
+	}
+
+	var flushed *LogEvent
+	if startsNewEvent {
+		flushed = b.Flush()
+		b.pending = NewLogEvent(timestampInMillis, line, filename, position)
+		b.pending.LogGeneratedTime = time.Now()
+	} else {
+		b.appendLine(line, position)
+	}
+	return flushed
+}
+
+//Flush returns the in-progress event, if any, and clears it so a later AddLine starts fresh.
+func (b *multilineLogEventBuffer) Flush() *LogEvent {
+	pending := b.pending
+	b.pending = nil
+	return pending
+}
+
+//FlushIfTimedOut returns and clears the pending event once it has been held longer than
+//cfg.FlushTimeout, even if no further line ever arrives to trigger the check inside
+//AddLine. Callers drive this from a periodic timer so an idle stream doesn't hold a
+//multi-line event forever.
+func (b *multilineLogEventBuffer) FlushIfTimedOut() *LogEvent {
+	if b.pending == nil || b.cfg.FlushTimeout <= 0 || time.Since(b.pending.LogGeneratedTime) < b.cfg.FlushTimeout {
+		return nil
+	}
+	return b.Flush()
+}
+
+//appendLine folds line onto the end of the pending event's message, re-applying the
+//256KB truncation logic since the merged message may now exceed it.
+func (b *multilineLogEventBuffer) appendLine(line string, position int64) {
+	merged := *b.pending.InputLogEvent.Message + "\n" + line
+	b.pending.InputLogEvent.Message = &merged
+	b.pending.FilePosition = position
+	b.pending.truncateIfNeeded()
+}
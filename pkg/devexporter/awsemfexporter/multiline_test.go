@@ -0,0 +1,65 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsemfexporter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestMultilineAddLineAppendsUntilStartPattern(t *testing.T) {
+	cfg := MultilineConfig{StartPattern: regexp.MustCompile(`^\[START\]`)}
+	b := newMultilineLogEventBuffer(cfg)
+
+	if flushed := b.AddLine(1000, "[START] first event", "f", 0); flushed != nil {
+		t.Fatalf("expected no flushed event for the very first line, got %v", *flushed.InputLogEvent.Message)
+	}
+	if flushed := b.AddLine(1001, "continuation line", "f", 1); flushed != nil {
+		t.Fatalf("expected a non-matching line to be folded into the pending event, not flushed")
+	}
+
+	flushed := b.AddLine(1002, "[START] second event", "f", 2)
+	if flushed == nil {
+		t.Fatalf("expected the first event to flush when the second [START] line arrives")
+	}
+	want := "[START] first event\ncontinuation line"
+	if got := *flushed.InputLogEvent.Message; got != want {
+		t.Fatalf("flushed message = %q, want %q", got, want)
+	}
+}
+
+func TestMultilineAddLineStampsLogGeneratedTime(t *testing.T) {
+	cfg := MultilineConfig{StartPattern: regexp.MustCompile(`^\[START\]`)}
+	b := newMultilineLogEventBuffer(cfg)
+
+	b.AddLine(1000, "[START] first event", "f", 0)
+	if b.pending.LogGeneratedTime.IsZero() {
+		t.Fatalf("expected AddLine to stamp LogGeneratedTime on the pending event")
+	}
+}
+
+func TestMultilineAddLineFlushesOnTimeout(t *testing.T) {
+	cfg := MultilineConfig{StartPattern: regexp.MustCompile(`^\[START\]`), FlushTimeout: time.Millisecond}
+	b := newMultilineLogEventBuffer(cfg)
+
+	b.AddLine(1000, "[START] first event", "f", 0)
+	time.Sleep(5 * time.Millisecond)
+
+	flushed := b.AddLine(1001, "not a start line", "f", 1)
+	if flushed == nil {
+		t.Fatalf("expected the pending event to flush once FlushTimeout has elapsed")
+	}
+}
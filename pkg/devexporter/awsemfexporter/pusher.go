@@ -15,12 +15,19 @@
 package awsemfexporter
 
 import (
+	"context"
 	"log"
+	"regexp"
 	"sort"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
 )
 
 const (
@@ -37,8 +44,26 @@ const (
 
 	logEventBatchPushChanBufferSize = 2 // processing part does not need to be blocked by the current put log event request
 	TruncatedSuffix                 = "[Truncated...]"
+
+	// flushTimeout is how often the background sender flushes a partial batch, mirroring the
+	// CloudWatch batch-publish frequency so bursty traffic doesn't sit unpublished indefinitely.
+	flushTimeout = 5 * time.Second
 )
 
+//expectedSequenceTokenPattern pulls the expected sequence token CloudWatch embeds in the
+//error message of both InvalidSequenceTokenException ("...next expected sequenceToken
+//is: <token>") and DataAlreadyAcceptedException ("...next batch can be sent with
+//sequenceToken: <token>").
+var expectedSequenceTokenPattern = regexp.MustCompile(`sequenceToken(?: is)?:\s*(\S+)`)
+
+func parseExpectedSequenceToken(message string) (string, bool) {
+	matches := expectedSequenceTokenPattern.FindStringSubmatch(message)
+	if len(matches) != 2 {
+		return "", false
+	}
+	return matches[1], true
+}
+
 //Struct to present a log event.
 type LogEvent struct {
 	InputLogEvent *cloudwatchlogs.InputLogEvent
@@ -50,20 +75,58 @@ type LogEvent struct {
 	LogGeneratedTime time.Time
 }
 
-//Calculate the log event payload bytes.
+//mTruncatedLogEvents counts log events truncated for exceeding MaxEventPayloadBytes,
+//registered below as a collector telemetry view so operators can see when payloads
+//are being clipped.
+var mTruncatedLogEvents = stats.Int64("awsemfexporter/truncated_log_events", "number of log events truncated for exceeding the CloudWatch event size limit", stats.UnitDimensionless)
+
+func init() {
+	_ = view.Register(&view.View{
+		Name:        mTruncatedLogEvents.Name(),
+		Description: mTruncatedLogEvents.Description(),
+		Measure:     mTruncatedLogEvents,
+		Aggregation: view.Count(),
+	})
+}
+
+//Calculate the log event payload bytes. CloudWatch's 256KB event limit is defined in
+//UTF-8 bytes, which is exactly what len() returns for a Go string, so no rune decoding
+//is needed here - it matters for truncateIfNeeded instead, which must not cut the byte
+//budget in the middle of a multi-byte rune.
 func (logEvent *LogEvent) eventPayloadBytes() int {
 	return len(*logEvent.InputLogEvent.Message) + PerEventHeaderBytes
 }
 
 func (logEvent *LogEvent) truncateIfNeeded() bool {
-	if logEvent.eventPayloadBytes() > MaxEventPayloadBytes {
-		log.Printf("W! logpusher: the single log event size is %v, which is larger than the max event payload allowed %v. Truncate the log event.", logEvent.eventPayloadBytes(), MaxEventPayloadBytes)
-		newPayload := (*logEvent.InputLogEvent.Message)[0:(MaxEventPayloadBytes - PerEventHeaderBytes - len(TruncatedSuffix))]
-		newPayload += TruncatedSuffix
-		logEvent.InputLogEvent.Message = &newPayload
-		return true
+	if logEvent.eventPayloadBytes() <= MaxEventPayloadBytes {
+		return false
+	}
+	log.Printf("W! logpusher: the single log event size is %v, which is larger than the max event payload allowed %v. Truncate the log event.", logEvent.eventPayloadBytes(), MaxEventPayloadBytes)
+	budget := MaxEventPayloadBytes - PerEventHeaderBytes - len(TruncatedSuffix)
+	newPayload := truncateToByteBudget(*logEvent.InputLogEvent.Message, budget) + TruncatedSuffix
+	logEvent.InputLogEvent.Message = &newPayload
+	stats.Record(context.Background(), mTruncatedLogEvents.M(1))
+	return true
+}
+
+//truncateToByteBudget returns the longest prefix of s whose UTF-8 byte length is at most
+//budget, walking backward from the budget to drop a trailing partial rune rather than
+//splitting it - slicing a UTF-8 string by a raw byte index that lands mid-rune (e.g. in
+//the middle of a CJK character or an emoji) produces an invalid payload CloudWatch will
+//reject.
+func truncateToByteBudget(s string, budget int) string {
+	if budget <= 0 {
+		return ""
+	}
+	if len(s) <= budget {
+		return s
+	}
+
+	cut := budget
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
 	}
-	return false
+	return s[:cut]
 }
 
 //Create a new log event
@@ -79,45 +142,94 @@ func NewLogEvent(timestampInMillis int64, message string, filename string, posit
 	return logEvent
 }
 
-//Struct to present a log event batch
-type LogEventBatch struct {
-	PutLogEventsInput *cloudwatchlogs.PutLogEventsInput
-	//the lastest file name for this log event.
-	FileName string
-	//the latest offset for this log file.
-	FilePosition int64
-	//the total bytes already in this log event batch
+//eventBatch owns the CloudWatch input events for a single in-flight batch along with
+//the accounting needed to decide when it is full: running byte total (including the
+//per-event header) and the min/max timestamps seen so far. It knows nothing about
+//pusher I/O, which keeps the accounting unit-testable on its own.
+type eventBatch struct {
+	logEvents []*cloudwatchlogs.InputLogEvent
+	//the total bytes already in this log event batch, including PerEventHeaderBytes per event
 	byteTotal int
 	//min timestamp recorded in this log event batch (ms)
 	minTimestampInMillis int64
 	//max timestamp recorded in this log event batch (ms)
 	maxTimestampInMillis int64
+}
 
-	creationTime time.Time
+func newEventBatch() *eventBatch {
+	return &eventBatch{
+		logEvents: make([]*cloudwatchlogs.InputLogEvent, 0, MaxRequestEventCount),
+	}
+}
+
+//full reports whether the batch has reached either of the PutLogEvents limits.
+func (b *eventBatch) full() bool {
+	return len(b.logEvents) == cap(b.logEvents) || b.byteTotal >= MaxRequestPayloadBytes
+}
+
+//add appends logEvent to the batch and returns nil, or leaves the batch untouched and
+//returns logEvent back as the "overflow" event when it doesn't fit (the batch is full,
+//the event would push byteTotal past MaxRequestPayloadBytes, or the event falls outside
+//the batch's 24 hour window). Callers chain the overflow event into a fresh batch
+//instead of dropping it.
+func (b *eventBatch) add(logEvent *LogEvent) (overflow *LogEvent) {
+	if b.full() ||
+		b.byteTotal+logEvent.eventPayloadBytes() > MaxRequestPayloadBytes ||
+		!b.timestampWithin24Hours(logEvent.InputLogEvent.Timestamp) {
+		return logEvent
+	}
+
+	b.logEvents = append(b.logEvents, logEvent.InputLogEvent)
+	b.byteTotal += logEvent.eventPayloadBytes()
+	if b.minTimestampInMillis == 0 || b.minTimestampInMillis > *logEvent.InputLogEvent.Timestamp {
+		b.minTimestampInMillis = *logEvent.InputLogEvent.Timestamp
+	}
+	if b.maxTimestampInMillis == 0 || b.maxTimestampInMillis < *logEvent.InputLogEvent.Timestamp {
+		b.maxTimestampInMillis = *logEvent.InputLogEvent.Timestamp
+	}
+	return nil
+}
+
+//reset clears the batch in place so it can be reused for the next round of events.
+func (b *eventBatch) reset() {
+	b.logEvents = b.logEvents[:0]
+	b.byteTotal = 0
+	b.minTimestampInMillis = 0
+	b.maxTimestampInMillis = 0
 }
 
 /**
  * A batch of log events in a single request cannot span more than 24 hours.
  * Otherwise, the operation fails.
  */
-func (logEventBatch *LogEventBatch) timestampWithin24Hours(targetInMillis *int64) bool {
+func (b *eventBatch) timestampWithin24Hours(targetInMillis *int64) bool {
 	//new log event batch
-	if logEventBatch.minTimestampInMillis == 0 || logEventBatch.maxTimestampInMillis == 0 {
+	if b.minTimestampInMillis == 0 || b.maxTimestampInMillis == 0 {
 		return true
 	}
-	if *targetInMillis-logEventBatch.minTimestampInMillis > 24*3600*1e3 {
+	if *targetInMillis-b.minTimestampInMillis > 24*3600*1e3 {
 		return false
 	}
-	if logEventBatch.maxTimestampInMillis-*targetInMillis > 24*3600*1e3 {
+	if b.maxTimestampInMillis-*targetInMillis > 24*3600*1e3 {
 		return false
 	}
 	return true
 }
 
 //Sort the log events based on the timestamp.
-func (logEventBatch *LogEventBatch) sortLogEvents() {
-	inputLogEvents := logEventBatch.PutLogEventsInput.LogEvents
-	sort.Stable(ByTimestamp(inputLogEvents))
+func (b *eventBatch) sortLogEvents() {
+	sort.Stable(ByTimestamp(b.logEvents))
+}
+
+//Struct to present a log event batch
+type LogEventBatch struct {
+	*eventBatch
+	//the lastest file name for this log event.
+	FileName string
+	//the latest offset for this log file.
+	FilePosition int64
+
+	creationTime time.Time
 }
 
 type ByTimestamp []*cloudwatchlogs.InputLogEvent
@@ -140,6 +252,15 @@ type Pusher interface {
 	ForceFlush() error
 }
 
+//logEventBatchRequest is handed off to the background sender goroutine through pushChan.
+//done is non-nil when the caller (ForceFlush) needs to block until CloudWatch has
+//acknowledged the batch; regular time/size-triggered flushes leave it nil and the
+//background goroutine only logs a failure instead of returning it anywhere.
+type logEventBatchRequest struct {
+	logEventBatch *LogEventBatch
+	done          chan error
+}
+
 //Struct of pusher implemented Pusher interface.
 type pusher struct {
 	//log group name for the current pusher
@@ -151,17 +272,44 @@ type pusher struct {
 	streamToken      string //no init value
 
 	logEventChan chan *LogEvent
-	pushChan     chan *LogEventBatch
+	pushChan     chan *logEventBatchRequest
 
+	//mutex guards logEventBatch, which is read/written both by callers of
+	//AddLogEntry/ForceFlush and by the background sender goroutine, as well as
+	//streamToken and retryCnt, which pushLogEventBatch and putLogEvents access under it.
+	mutex         sync.Mutex
 	logEventBatch *LogEventBatch
 	retryCnt      int
+
+	//batchPool recycles the *eventBatch backing array across pushes instead of letting it
+	//go to the GC every time a batch is published, since a fresh batch is needed on every
+	//full/periodic/force flush.
+	batchPool sync.Pool
+
+	//multiline aggregates lines into multi-line log events before they ever reach
+	//logEventBatch; it is nil when multilineConfig.StartPattern is nil, so AddLogEntry
+	//behaves exactly as before for pushers that don't configure multiline aggregation.
+	multiline *multilineLogEventBuffer
 }
 
-//Create a pusher instance and start the instance afterwards
+//NewPusher creates a pusher instance and starts its background sender goroutine.
 func NewPusher(logGroupName, logStreamName *string, retryCnt int,
-	svcStructuredLog LogClient) Pusher {
+	svcStructuredLog LogClient, multilineConfig MultilineConfig) Pusher {
+	return newPusher(logGroupName, logStreamName, retryCnt, svcStructuredLog, multilineConfig)
+}
 
-	pusher := newPusher(logGroupName, logStreamName, svcStructuredLog)
+//newPusher creates a pusher and starts its background sender goroutine, so every
+//returned *pusher is always running - there is no separate, not-yet-started state for
+//callers to accidentally send to and block on.
+func newPusher(logGroupName, logStreamName *string, retryCnt int,
+	svcStructuredLog LogClient, multilineConfig MultilineConfig) *pusher {
+	pusher := &pusher{
+		logGroupName:     logGroupName,
+		logStreamName:    logStreamName,
+		svcStructuredLog: svcStructuredLog,
+		logEventChan:     make(chan *LogEvent, logEventChanBufferSize),
+		pushChan:         make(chan *logEventBatchRequest, logEventBatchPushChanBufferSize),
+	}
 
 	// For blocking queue, assuming the log batch payload size is 1MB. Set queue size to 2
 	// For nonblocking queue, assuming the log batch payload size is much less than 1MB. Set queue size to 20
@@ -169,24 +317,70 @@ func NewPusher(logGroupName, logStreamName *string, retryCnt int,
 	if retryCnt > 0 {
 		pusher.retryCnt = retryCnt
 	}
-	return pusher
-}
 
-//Only create a pusher, but not start the instance.
-func newPusher(logGroupName, logStreamName *string,
-	svcStructuredLog LogClient) *pusher {
-	pusher := &pusher{
-		logGroupName:     logGroupName,
-		logStreamName:    logStreamName,
-		svcStructuredLog: svcStructuredLog,
-		logEventChan:     make(chan *LogEvent, logEventChanBufferSize),
-		pushChan:         make(chan *LogEventBatch, logEventBatchPushChanBufferSize),
+	if multilineConfig.StartPattern != nil {
+		pusher.multiline = newMultilineLogEventBuffer(multilineConfig)
 	}
 
 	pusher.logEventBatch = pusher.newLogEventBatch()
+	go pusher.start()
 	return pusher
 }
 
+//start runs on its own goroutine for the lifetime of the pusher, serializing every
+//PutLogEvents call so streamToken/retryCnt never need to be touched from more than
+//one place at a time. It also owns the periodic ticker that flushes a partial batch
+//when traffic is bursty enough that the batch never fills up on its own.
+func (p *pusher) start() {
+	ticker := time.NewTicker(flushTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case req, ok := <-p.pushChan:
+			if !ok {
+				return
+			}
+			err := p.pushLogEventBatch(req.logEventBatch)
+			p.recycleBatch(req.logEventBatch)
+			if req.done != nil {
+				req.done <- err
+			} else if err != nil {
+				log.Printf("E! logpusher: failed to publish log event batch for (%v/%v): %v", p.logGroupName, p.logStreamName, err)
+			}
+		case <-ticker.C:
+			p.periodicFlush()
+		}
+	}
+}
+
+//periodicFlush swaps out the current batch, if non-empty, and pushes it on the
+//background goroutine itself so a slow trickle of events still gets published
+//roughly every flushTimeout instead of waiting for the batch to fill up. It also drives
+//multiline's FlushTimeout proactively, since an idle stream otherwise never feeds
+//AddLine another line to notice the timeout has elapsed.
+func (p *pusher) periodicFlush() {
+	if p.multiline != nil {
+		if pending := p.flushMultilineIfTimedOut(); pending != nil {
+			p.addLogEvent(pending)
+		}
+	}
+
+	p.mutex.Lock()
+	logEventBatch := p.logEventBatch
+	if len(logEventBatch.logEvents) == 0 {
+		p.mutex.Unlock()
+		return
+	}
+	p.logEventBatch = p.newLogEventBatch()
+	p.mutex.Unlock()
+
+	err := p.pushLogEventBatch(logEventBatch)
+	p.recycleBatch(logEventBatch)
+	if err != nil {
+		log.Printf("E! logpusher: failed to publish log event batch for (%v/%v): %v", p.logGroupName, p.logStreamName, err)
+	}
+}
+
 // Besides the limit specified by PutLogEvents API, there are some overall limit for the cloudwatchlogs
 // listed here: http://docs.aws.amazon.com/AmazonCloudWatch/latest/logs/cloudwatch_limits_cwl.html
 //
@@ -200,40 +394,100 @@ func (p *pusher) AddLogEntry(logEvent *LogEvent) error {
 		if *logEvent.InputLogEvent.Timestamp == int64(0) {
 			logEvent.InputLogEvent.Timestamp = aws.Int64(logEvent.LogGeneratedTime.UnixNano() / 1e6)
 		}
+		if p.multiline != nil {
+			logEvent = p.addLineToMultiline(logEvent)
+			if logEvent == nil {
+				return nil
+			}
+		}
 		err = p.addLogEvent(logEvent)
 	}
 	return err
 }
 
+//addLineToMultiline feeds logEvent through p.multiline under p.mutex:
+//multilineLogEventBuffer does no locking of its own, but AddLogEntry is called
+//concurrently by multiple producer goroutines by design (see addLogEvent).
+func (p *pusher) addLineToMultiline(logEvent *LogEvent) *LogEvent {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.multiline.AddLine(*logEvent.InputLogEvent.Timestamp, *logEvent.InputLogEvent.Message, logEvent.FileName, logEvent.FilePosition)
+}
+
+//flushMultilineIfTimedOut returns and clears p.multiline's pending event once its
+//FlushTimeout has elapsed, taking p.mutex for the same reason as addLineToMultiline.
+func (p *pusher) flushMultilineIfTimedOut() *LogEvent {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.multiline.FlushIfTimedOut()
+}
+
+//flushMultiline unconditionally returns and clears p.multiline's pending event, taking
+//p.mutex for the same reason as addLineToMultiline.
+func (p *pusher) flushMultiline() *LogEvent {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.multiline.Flush()
+}
+
+//ForceFlush blocks until the current batch, if any, has actually been
+//acknowledged by CloudWatch, so callers (e.g. shutdown paths) know the data
+//landed before they return. Any line still held by multiline (waiting for a
+//terminating line that never arrived) is flushed first so it isn't lost.
 func (p *pusher) ForceFlush() error {
-	return p.flushLogEventBatch()
+	if p.multiline != nil {
+		if pending := p.flushMultiline(); pending != nil {
+			p.addLogEvent(pending)
+		}
+	}
+
+	p.mutex.Lock()
+	logEventBatch := p.logEventBatch
+	if len(logEventBatch.logEvents) == 0 {
+		p.mutex.Unlock()
+		return nil
+	}
+	p.logEventBatch = p.newLogEventBatch()
+	p.mutex.Unlock()
+
+	done := make(chan error, 1)
+	p.pushChan <- &logEventBatchRequest{logEventBatch: logEventBatch, done: done}
+	return <-done
 }
 
-func (p *pusher) pushLogEventBatch(req interface{}) error {
+//pushLogEventBatch is only ever invoked from the background sender goroutine started
+//in start(), so streamToken/retryCnt accesses below are already serialized; the mutex
+//is still taken around them, as the pusher struct's mutex comment lists them as guarded.
+func (p *pusher) pushLogEventBatch(logEventBatch *LogEventBatch) error {
 	//http://docs.aws.amazon.com/goto/SdkForGoV1/logs-2014-03-28/PutLogEvents
 	//* The log events in the batch must be in chronological ordered by their
 	//timestamp (the time the event occurred, expressed as the number of milliseconds
 	//since Jan 1, 1970 00:00:00 UTC).
-	logEventBatch := req.(*LogEventBatch)
 	logEventBatch.sortLogEvents()
-	putLogEventsInput := logEventBatch.PutLogEventsInput
+	putLogEventsInput := &cloudwatchlogs.PutLogEventsInput{
+		LogGroupName:  p.logGroupName,
+		LogStreamName: p.logStreamName,
+		LogEvents:     logEventBatch.logEvents,
+	}
+
+	p.mutex.Lock()
+	streamToken := p.streamToken
+	retryCnt := p.retryCnt
+	p.mutex.Unlock()
 
-	if p.streamToken == "" {
+	if streamToken == "" {
 		//log part and retry logic are already done inside the CreateStream
 		// when the error is not nil, the stream token is "", which is handled in the below logic.
-		p.streamToken, _ = p.svcStructuredLog.CreateStream(p.logGroupName, p.logStreamName)
+		streamToken, _ = p.svcStructuredLog.CreateStream(p.logGroupName, p.logStreamName)
 	}
 
-	if p.streamToken != "" {
-		putLogEventsInput.SequenceToken = aws.String(p.streamToken)
+	if streamToken != "" {
+		putLogEventsInput.SequenceToken = aws.String(streamToken)
 	}
 
 	startTime := time.Now()
 
-	var tmpToken *string
-	var err error
-	tmpToken, err = p.svcStructuredLog.PutLogEvents(putLogEventsInput, p.retryCnt)
-
+	tmpToken, err := p.putLogEvents(putLogEventsInput, retryCnt)
 	if err != nil {
 		return err
 	}
@@ -244,7 +498,9 @@ func (p *pusher) pushLogEventBatch(req interface{}) error {
 		time.Since(startTime).Nanoseconds()/1e6)
 
 	if tmpToken != nil {
+		p.mutex.Lock()
 		p.streamToken = *tmpToken
+		p.mutex.Unlock()
 	}
 	diff := time.Since(startTime)
 	if timeLeft := minPusherIntervalInMillis*time.Millisecond - diff; timeLeft > 0 {
@@ -253,43 +509,95 @@ func (p *pusher) pushLogEventBatch(req interface{}) error {
 	return nil
 }
 
-//Create a new log event batch if needed.
+//putLogEvents calls PutLogEvents and transparently recovers from the two most common
+//CloudWatch errors that carry the correct expectedSequenceToken in their error message:
+//InvalidSequenceTokenException (token drift, e.g. after another writer or a restart) and
+//DataAlreadyAcceptedException (this batch was already submitted once). Both update
+//p.streamToken so it stays in sync with CloudWatch's view. This lives here, rather than
+//in LogClient, so the token state stays consistent with the pusher's local cache.
+func (p *pusher) putLogEvents(putLogEventsInput *cloudwatchlogs.PutLogEventsInput, retryCnt int) (*string, error) {
+	tmpToken, err := p.svcStructuredLog.PutLogEvents(putLogEventsInput, retryCnt)
+	if err == nil {
+		return tmpToken, nil
+	}
+
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return nil, err
+	}
+
+	switch awsErr.Code() {
+	case cloudwatchlogs.ErrCodeInvalidSequenceTokenException:
+		expectedToken, found := parseExpectedSequenceToken(awsErr.Message())
+		if !found {
+			return nil, err
+		}
+		log.Printf("I! logpusher: sequence token for (%v/%v) is stale, retrying once with the expected token.", p.logGroupName, p.logStreamName)
+		p.mutex.Lock()
+		p.streamToken = expectedToken
+		p.mutex.Unlock()
+		putLogEventsInput.SequenceToken = aws.String(expectedToken)
+		//retry the same batch once with the expected token instead of going through the
+		//generic retry backoff, since we already know exactly what went wrong.
+		return p.svcStructuredLog.PutLogEvents(putLogEventsInput, retryCnt)
+	case cloudwatchlogs.ErrCodeDataAlreadyAcceptedException:
+		expectedToken, found := parseExpectedSequenceToken(awsErr.Message())
+		if !found {
+			return nil, err
+		}
+		log.Printf("I! logpusher: log event batch for (%v/%v) was already accepted by CloudWatch, treating as success.", p.logGroupName, p.logStreamName)
+		p.mutex.Lock()
+		p.streamToken = expectedToken
+		p.mutex.Unlock()
+		return aws.String(expectedToken), nil
+	default:
+		return nil, err
+	}
+}
+
+//newLogEventBatch creates a new log event batch, reusing a recycled *eventBatch from
+//batchPool when one is available instead of always allocating a fresh one.
 func (p *pusher) newLogEventBatch() *LogEventBatch {
-	logEventBatch := &LogEventBatch{
-		PutLogEventsInput: &cloudwatchlogs.PutLogEventsInput{
-			LogGroupName:  p.logGroupName,
-			LogStreamName: p.logStreamName,
-			LogEvents:     make([]*cloudwatchlogs.InputLogEvent, 0, MaxRequestEventCount)},
+	eb, ok := p.batchPool.Get().(*eventBatch)
+	if !ok {
+		eb = newEventBatch()
+	}
+	return &LogEventBatch{
+		eventBatch:   eb,
 		creationTime: time.Now(),
 	}
-	return logEventBatch
 }
 
-//Determine if a new log event batch is needed.
-func (p *pusher) newLogEventBatchIfNeeded(logEvent *LogEvent) error {
-	var err error
-	logEventBatch := p.logEventBatch
-	if len(logEventBatch.PutLogEventsInput.LogEvents) == cap(logEventBatch.PutLogEventsInput.LogEvents) ||
-		logEvent != nil && (logEventBatch.byteTotal+logEvent.eventPayloadBytes() > MaxRequestPayloadBytes || !logEventBatch.timestampWithin24Hours(logEvent.InputLogEvent.Timestamp)) {
-		err = p.pushLogEventBatch(logEventBatch)
-		p.logEventBatch = p.newLogEventBatch()
-	}
-	return err
+//recycleBatch resets logEventBatch's underlying eventBatch and returns it to batchPool
+//so the next newLogEventBatch call can reuse its backing array.
+func (p *pusher) recycleBatch(logEventBatch *LogEventBatch) {
+	logEventBatch.eventBatch.reset()
+	p.batchPool.Put(logEventBatch.eventBatch)
 }
 
-func (p *pusher) flushLogEventBatch() error {
-	var err error
-	if len(p.logEventBatch.PutLogEventsInput.LogEvents) > 0 {
-		logEventBatch := p.logEventBatch
-		err = p.pushLogEventBatch(logEventBatch)
-		p.logEventBatch = p.newLogEventBatch()
+//appendToBatch adds logEvent to the current batch, swapping in a fresh batch and
+//chaining the overflow event into it when the current one is full. Must be called
+//with p.mutex held. Returns the outgoing batch when one was swapped out, so the
+//caller can hand it off to the background sender goroutine once the mutex is released.
+func (p *pusher) appendToBatch(logEvent *LogEvent) (outgoing *LogEventBatch) {
+	overflow := p.logEventBatch.add(logEvent)
+	if overflow == nil {
+		p.logEventBatch.FileName = logEvent.FileName
+		p.logEventBatch.FilePosition = logEvent.FilePosition
+		return nil
 	}
-	return err
+
+	outgoing = p.logEventBatch
+	p.logEventBatch = p.newLogEventBatch()
+	//a freshly reset batch always has room for a single already-truncated event
+	p.logEventBatch.add(overflow)
+	p.logEventBatch.FileName = overflow.FileName
+	p.logEventBatch.FilePosition = overflow.FilePosition
+	return outgoing
 }
 
-//Add the log event onto the log event batch
+//Add the log event onto the log event batch. Safe to call from multiple goroutines.
 func (p *pusher) addLogEvent(logEvent *LogEvent) error {
-	var err error
 	if len(*logEvent.InputLogEvent.Message) == 0 {
 		return nil
 	}
@@ -304,24 +612,15 @@ func (p *pusher) addLogEvent(logEvent *LogEvent) error {
 	duration := currentTime.Sub(utcTime).Hours()
 	if duration > 24*14 || duration < -2 {
 		log.Printf("E! logpusher: the log entry in (%v/%v) with timestamp (%v) comparing to the current time (%v) is older than 14 days or more than 2 hours in the future. Discard the log entry.", p.logGroupName, logEvent.FileName, utcTime, currentTime)
-		return err
+		return nil
 	}
 
-	err = p.newLogEventBatchIfNeeded(logEvent)
-	if err != nil {
-		return err
-	}
-	logEventBatch := p.logEventBatch
+	p.mutex.Lock()
+	outgoing := p.appendToBatch(logEvent)
+	p.mutex.Unlock()
 
-	logEventBatch.PutLogEventsInput.LogEvents = append(logEventBatch.PutLogEventsInput.LogEvents, logEvent.InputLogEvent)
-	logEventBatch.byteTotal += logEvent.eventPayloadBytes()
-	logEventBatch.FileName = logEvent.FileName
-	logEventBatch.FilePosition = logEvent.FilePosition
-	if logEventBatch.minTimestampInMillis == 0 || logEventBatch.minTimestampInMillis > *logEvent.InputLogEvent.Timestamp {
-		logEventBatch.minTimestampInMillis = *logEvent.InputLogEvent.Timestamp
-	}
-	if logEventBatch.maxTimestampInMillis == 0 || logEventBatch.maxTimestampInMillis < *logEvent.InputLogEvent.Timestamp {
-		logEventBatch.maxTimestampInMillis = *logEvent.InputLogEvent.Timestamp
+	if outgoing != nil {
+		p.pushChan <- &logEventBatchRequest{logEventBatch: outgoing}
 	}
 	return nil
 }
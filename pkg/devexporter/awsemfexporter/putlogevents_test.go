@@ -0,0 +1,132 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsemfexporter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+)
+
+func TestParseExpectedSequenceToken(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantToken string
+		wantFound bool
+	}{
+		{
+			name:      "invalid sequence token message",
+			message:   "The given sequenceToken is invalid. The next expected sequenceToken is: 12345",
+			wantToken: "12345",
+			wantFound: true,
+		},
+		{
+			name:      "data already accepted message",
+			message:   "The given batch of log events has already been accepted. The next batch can be sent with sequenceToken: 67890",
+			wantToken: "67890",
+			wantFound: true,
+		},
+		{
+			name:    "no token in message",
+			message: "some unrelated error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, found := parseExpectedSequenceToken(tt.message)
+			if found != tt.wantFound || token != tt.wantToken {
+				t.Fatalf("parseExpectedSequenceToken(%q) = (%q, %v), want (%q, %v)", tt.message, token, found, tt.wantToken, tt.wantFound)
+			}
+		})
+	}
+}
+
+func newTestPusher(client LogClient) *pusher {
+	return newPusher(aws.String("group"), aws.String("stream"), 1, client, MultilineConfig{})
+}
+
+func TestPutLogEventsRetriesOnInvalidSequenceToken(t *testing.T) {
+	client := &mockLogClient{
+		responses: []mockPutLogEventsResponse{
+			{err: awserr.New(cloudwatchlogs.ErrCodeInvalidSequenceTokenException, "The next expected sequenceToken is: expected-token", nil)},
+			{token: aws.String("expected-token")},
+		},
+	}
+	p := newTestPusher(client)
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: p.logGroupName, LogStreamName: p.logStreamName}
+	token, err := p.putLogEvents(input, 1)
+	if err != nil {
+		t.Fatalf("putLogEvents failed: %v", err)
+	}
+	if token == nil || *token != "expected-token" {
+		t.Fatalf("expected the retried token %q, got %v", "expected-token", token)
+	}
+	if p.streamToken != "expected-token" {
+		t.Fatalf("expected p.streamToken to be updated to %q, got %q", "expected-token", p.streamToken)
+	}
+}
+
+func TestPutLogEventsTreatsDataAlreadyAcceptedAsSuccess(t *testing.T) {
+	client := &mockLogClient{
+		responses: []mockPutLogEventsResponse{
+			{err: awserr.New(cloudwatchlogs.ErrCodeDataAlreadyAcceptedException, "The next batch can be sent with sequenceToken: already-accepted-token", nil)},
+		},
+	}
+	p := newTestPusher(client)
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: p.logGroupName, LogStreamName: p.logStreamName}
+	token, err := p.putLogEvents(input, 1)
+	if err != nil {
+		t.Fatalf("putLogEvents failed: %v", err)
+	}
+	if token == nil || *token != "already-accepted-token" {
+		t.Fatalf("expected token %q, got %v", "already-accepted-token", token)
+	}
+	if len(client.responses) != 0 {
+		t.Fatalf("DataAlreadyAccepted should be treated as success without retrying PutLogEvents")
+	}
+}
+
+func TestPutLogEventsReturnsErrorWhenTokenMissingFromMessage(t *testing.T) {
+	client := &mockLogClient{
+		responses: []mockPutLogEventsResponse{
+			{err: awserr.New(cloudwatchlogs.ErrCodeInvalidSequenceTokenException, "no token in this message", nil)},
+		},
+	}
+	p := newTestPusher(client)
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: p.logGroupName, LogStreamName: p.logStreamName}
+	if _, err := p.putLogEvents(input, 1); err == nil {
+		t.Fatalf("expected an error when the CloudWatch message doesn't carry a parseable token")
+	}
+}
+
+func TestPutLogEventsReturnsNonAWSErrorUnchanged(t *testing.T) {
+	wantErr := errors.New("network blip")
+	client := &mockLogClient{responses: []mockPutLogEventsResponse{{err: wantErr}}}
+	p := newTestPusher(client)
+
+	input := &cloudwatchlogs.PutLogEventsInput{LogGroupName: p.logGroupName, LogStreamName: p.logStreamName}
+	_, err := p.putLogEvents(input, 1)
+	if err != wantErr {
+		t.Fatalf("expected the non-AWS error to be returned unchanged, got %v", err)
+	}
+}
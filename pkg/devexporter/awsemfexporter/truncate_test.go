@@ -0,0 +1,76 @@
+// Copyright 2020, OpenTelemetry Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsemfexporter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateIfNeededASCII(t *testing.T) {
+	message := strings.Repeat("a", MaxEventPayloadBytes)
+	logEvent := NewLogEvent(1000, message, "f", 0)
+
+	if !logEvent.truncateIfNeeded() {
+		t.Fatalf("expected an oversized ASCII message to be truncated")
+	}
+	assertWithinBudgetAndValidUTF8(t, *logEvent.InputLogEvent.Message)
+}
+
+func TestTruncateIfNeededCJKAndEmoji(t *testing.T) {
+	// repeat a mix of multi-byte runes until the message is well past the limit, so the
+	// byte budget is very unlikely to land on a rune boundary by chance.
+	message := strings.Repeat("日本語😀", MaxEventPayloadBytes)
+	logEvent := NewLogEvent(1000, message, "f", 0)
+
+	if !logEvent.truncateIfNeeded() {
+		t.Fatalf("expected an oversized multi-byte message to be truncated")
+	}
+	assertWithinBudgetAndValidUTF8(t, *logEvent.InputLogEvent.Message)
+}
+
+func TestTruncateIfNeededSingleHugeRuneStream(t *testing.T) {
+	// a single repeated 4-byte rune with no ASCII anywhere. The byte budget
+	// (MaxEventPayloadBytes - PerEventHeaderBytes - len(TruncatedSuffix) = 262104) is a
+	// multiple of 4, so here the cut falls on a rune boundary.
+	message := strings.Repeat("😀", MaxEventPayloadBytes)
+	logEvent := NewLogEvent(1000, message, "f", 0)
+
+	if !logEvent.truncateIfNeeded() {
+		t.Fatalf("expected the 300KB+ single-rune stream to be truncated")
+	}
+	assertWithinBudgetAndValidUTF8(t, *logEvent.InputLogEvent.Message)
+}
+
+func TestTruncateIfNeededWithinLimit(t *testing.T) {
+	logEvent := NewLogEvent(1000, "hello world", "f", 0)
+	if logEvent.truncateIfNeeded() {
+		t.Fatalf("a message within MaxEventPayloadBytes should not be truncated")
+	}
+}
+
+func assertWithinBudgetAndValidUTF8(t *testing.T, message string) {
+	t.Helper()
+	if got := len(message) + PerEventHeaderBytes; got > MaxEventPayloadBytes {
+		t.Fatalf("truncated payload is %d bytes, want <= %d", got, MaxEventPayloadBytes)
+	}
+	if !utf8.ValidString(message) {
+		t.Fatalf("truncated message %q is not valid UTF-8", message)
+	}
+	if !strings.HasSuffix(message, TruncatedSuffix) {
+		t.Fatalf("truncated message should end with %q", TruncatedSuffix)
+	}
+}